@@ -0,0 +1,197 @@
+package sim900
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CallState is the lifecycle stage of a voice call.
+type CallState int
+
+// Call states, in the order a normal outgoing call progresses through them. An
+// incoming call accepted via IncomingCall.Accept starts at CallActive.
+const (
+	CallDialing CallState = iota
+	CallRinging
+	CallActive
+	CallEnded
+)
+
+func (st CallState) String() string {
+	switch st {
+	case CallDialing:
+		return "dialing"
+	case CallRinging:
+		return "ringing"
+	case CallActive:
+		return "active"
+	case CallEnded:
+		return "ended"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	confPattern    = regexp.MustCompile(`\^CONF:\d+`)
+	connPattern    = regexp.MustCompile(`\^CONN:\d+`)
+	endCallPattern = regexp.MustCompile(`\^CEND:\d+`)
+)
+
+// Call is a handle to an in-progress or active voice call, dialed with SIM900.Call or
+// answered with IncomingCall.Accept.
+type Call struct {
+	modem       *SIM900
+	PhoneNumber string
+
+	stateCh    chan CallState
+	listenerID uint32
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newCall(modem *SIM900, phoneNumber string) *Call {
+	c := &Call{
+		modem:       modem,
+		PhoneNumber: phoneNumber,
+		stateCh:     make(chan CallState, 8),
+	}
+	c.listenerID = modem.Port.AddOutputListener(c.onOutput)
+	return c
+}
+
+// onOutput derives call state from the ^CONF/^CONN/^CEND URCs the SIM900 emits while
+// dialing (^ORIG is the dial attempt itself, already implied by CallDialing).
+func (c *Call) onOutput(b []byte) {
+	body := string(b)
+	switch {
+	case confPattern.MatchString(body):
+		c.setState(CallRinging)
+	case connPattern.MatchString(body):
+		c.setState(CallActive)
+	case endCallPattern.MatchString(body):
+		c.setState(CallEnded)
+		c.close()
+	}
+}
+
+// setState is called both from onOutput (on the listener goroutine) and from Hangup/Accept
+// (on the caller's goroutine), so it must never send on stateCh concurrently with close
+// closing it; mu makes the check-and-send and the close mutually exclusive.
+func (c *Call) setState(st CallState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.stateCh <- st:
+	default:
+	}
+}
+
+func (c *Call) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.modem.Port.DelOutputListener(c.listenerID)
+	close(c.stateCh)
+}
+
+// State returns a channel emitting this call's lifecycle transitions. It is closed once
+// the call ends.
+func (c *Call) State() <-chan CallState {
+	return c.stateCh
+}
+
+// Hangup ends the call (ATH).
+func (c *Call) Hangup() error {
+	c.modem.PortMu.Lock()
+	_, err := c.modem.wait4response("ATH", CMD_OK, time.Second*5)
+	c.modem.PortMu.Unlock()
+	c.setState(CallEnded)
+	c.close()
+	return err
+}
+
+// SendDTMF sends each digit in sequence as an in-call DTMF tone (AT+VTS=).
+func (c *Call) SendDTMF(digits string) error {
+	for _, d := range digits {
+		c.modem.PortMu.Lock()
+		_, err := c.modem.wait4response(fmt.Sprintf("AT+VTS=%c", d), CMD_OK, time.Second*5)
+		c.modem.PortMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Call dials phoneNumber (ATD) and returns a handle tracking the call's lifecycle
+// through Call.State(): Dialing, then Ringing/Active as ^CONF/^CONN URCs arrive, and
+// Ended on hangup, rejection or failure (^CEND). The dial write is serialized against
+// other AT transactions via PortMu, the same invariant SendSMS/gprs hold.
+func (s *SIM900) Call(phoneNumber string) (*Call, error) {
+	c := newCall(s, phoneNumber)
+	s.PortMu.Lock()
+	err := s.Port.Println("ATD" + phoneNumber + ";")
+	s.PortMu.Unlock()
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	c.setState(CallDialing)
+	return c, nil
+}
+
+// IncomingCall represents a call ringing on the modem that hasn't been answered yet.
+type IncomingCall struct {
+	modem       *SIM900
+	PhoneNumber string
+}
+
+// Accept answers the call (ATA) and returns a *Call handle to track/control it.
+func (ic *IncomingCall) Accept() (*Call, error) {
+	c := newCall(ic.modem, ic.PhoneNumber)
+	ic.modem.PortMu.Lock()
+	_, err := ic.modem.wait4response("ATA", CMD_OK, time.Second*5)
+	ic.modem.PortMu.Unlock()
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	c.setState(CallActive)
+	return c, nil
+}
+
+// Reject declines the call (ATH) without answering it.
+func (ic *IncomingCall) Reject() error {
+	ic.modem.PortMu.Lock()
+	defer ic.modem.PortMu.Unlock()
+	_, err := ic.modem.wait4response("ATH", CMD_OK, time.Second*5)
+	return err
+}
+
+// AddCallListener registers fn to be invoked whenever the modem reports an incoming
+// call, replacing the old single-shot OnNewCall field.
+func (s *SIM900) AddCallListener(fn func(*IncomingCall)) uint64 {
+	id := atomic.AddUint64(&s.nextCallEvent, 1)
+	s.CallEventLock.Lock()
+	s.mapCallEvents[id] = fn
+	s.CallEventLock.Unlock()
+	return id
+}
+
+// DelCallListener removes a listener registered with AddCallListener.
+func (s *SIM900) DelCallListener(id uint64) {
+	s.CallEventLock.Lock()
+	delete(s.mapCallEvents, id)
+	s.CallEventLock.Unlock()
+}
@@ -0,0 +1,59 @@
+package sim900
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+var cdsPattern = regexp.MustCompile(`\+CDS:[\s,\d]+\r?\n([a-zA-Z\d]+)(\r?\n|$)`)
+
+// AddDeliveryReportListener registers fn to be called whenever the modem reports an
+// SMS-STATUS-REPORT (+CDS:) for a previously sent message. id is the numeric message
+// reference that SendSMS/SendLongSMS returned (the same value +CMGS: reported).
+func (s *SIM900) AddDeliveryReportListener(fn func(id string, delivered bool)) uint64 {
+	id := atomic.AddUint64(&s.nextDeliveryEvent, 1)
+	s.DeliveryEventLock.Lock()
+	s.mapDeliveryEvents[id] = fn
+	s.DeliveryEventLock.Unlock()
+	return id
+}
+
+// DelDeliveryReportListener removes a listener registered with AddDeliveryReportListener.
+func (s *SIM900) DelDeliveryReportListener(id uint64) {
+	s.DeliveryEventLock.Lock()
+	delete(s.mapDeliveryEvents, id)
+	s.DeliveryEventLock.Unlock()
+}
+
+// parseCDS extracts the message reference and delivered/failed outcome from a raw
+// SMS-STATUS-REPORT PDU. TP-Status 0 means delivered; any other value is treated as
+// failed, which simplifies the full TP-Status code table to a binary outcome.
+func parseCDS(pdu []byte) (ref byte, delivered bool, ok bool) {
+	if len(pdu) < 1 {
+		return 0, false, false
+	}
+	offset := 1 + int(pdu[0]) // skip SMSC info
+
+	if len(pdu) < offset+2 {
+		return 0, false, false
+	}
+	offset++ // PDU type octet
+	ref = pdu[offset]
+	offset++
+
+	if len(pdu) < offset+1 {
+		return 0, false, false
+	}
+	addrDigits := int(pdu[offset])
+	offset++
+	addrBytes := (addrDigits + 1) / 2
+	offset += 1 + addrBytes // address type octet + address digits
+
+	offset += 7 // SCTS
+	offset += 7 // discharge time
+
+	if len(pdu) < offset+1 {
+		return 0, false, false
+	}
+	return ref, pdu[offset] == 0, true
+}
@@ -0,0 +1,51 @@
+package sim900
+
+import "testing"
+
+// cdsPDU builds a minimal SMS-STATUS-REPORT PDU with no SMSC info and a zero-length
+// originating address, enough to exercise parseCDS's offset walk.
+func cdsPDU(ref byte, status byte) []byte {
+	pdu := []byte{
+		0x00, // SMSC info length
+		0x06, // PDU type octet
+		ref,  // TP-MR
+		0x00, // address digit count
+		0x00, // address type octet
+	}
+	pdu = append(pdu, make([]byte, 7)...) // SCTS
+	pdu = append(pdu, make([]byte, 7)...) // discharge time
+	pdu = append(pdu, status)             // TP-Status
+	return pdu
+}
+
+func TestParseCDSDelivered(t *testing.T) {
+	ref, delivered, ok := parseCDS(cdsPDU(42, 0x00))
+	if !ok {
+		t.Fatal("expected ok")
+	}
+	if ref != 42 {
+		t.Fatalf("expected ref 42, got %d", ref)
+	}
+	if !delivered {
+		t.Fatal("expected delivered")
+	}
+}
+
+func TestParseCDSFailed(t *testing.T) {
+	ref, delivered, ok := parseCDS(cdsPDU(7, 0x43))
+	if !ok {
+		t.Fatal("expected ok")
+	}
+	if ref != 7 {
+		t.Fatalf("expected ref 7, got %d", ref)
+	}
+	if delivered {
+		t.Fatal("expected not delivered")
+	}
+}
+
+func TestParseCDSTruncated(t *testing.T) {
+	if _, _, ok := parseCDS([]byte{0x00, 0x06, 0x2A}); ok {
+		t.Fatal("expected ok=false for a truncated PDU")
+	}
+}
@@ -0,0 +1,234 @@
+// Package gprs brings up a GPRS/PDP context on a SIM900 modem and exposes TCP sockets
+// opened over it as net.Conn, backed by AT+CIPSEND writes and +IPD receive parsing.
+package gprs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vinhjaxt/sim900"
+)
+
+// waitResponse mirrors SIM900's own unexported wait4response: it waits for expected (or
+// an ERROR) on port, running cmd/inits. Callers must hold modem.PortMu for the duration.
+func waitResponse(port sim900.Transport, cmd, expected string, timeout time.Duration, inits ...func() error) ([]string, error) {
+	response, err := port.WaitForRegexTimeout(cmd, expected+`|(^|\W)ERROR($|\W)`, timeout, inits...)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(response[0], "ERROR") {
+		return nil, errors.New("gprs: " + response[0])
+	}
+	return response, nil
+}
+
+// Context is an active GPRS/PDP context on a SIM900 modem.
+type Context struct {
+	modem *sim900.SIM900
+}
+
+// Bringup attaches to GPRS and brings up a PDP context for apn (AT+CGATT, AT+CSTT,
+// AT+CIICR, AT+CIFSR). user/pass may be empty if the APN doesn't require them.
+func Bringup(modem *sim900.SIM900, apn, user, pass string) (*Context, error) {
+	modem.PortMu.Lock()
+	defer modem.PortMu.Unlock()
+
+	if _, err := waitResponse(modem.Port, "AT+CGATT=1", sim900.CMD_OK, time.Second*10); err != nil {
+		return nil, err
+	}
+
+	cmd := fmt.Sprintf(`AT+CSTT="%s","%s","%s"`, apn, user, pass)
+	if _, err := waitResponse(modem.Port, cmd, sim900.CMD_OK, time.Second*10); err != nil {
+		return nil, err
+	}
+
+	if _, err := waitResponse(modem.Port, "AT+CIICR", sim900.CMD_OK, time.Second*20); err != nil {
+		return nil, err
+	}
+
+	if _, err := waitResponse(modem.Port, "AT+CIFSR", `\d+\.\d+\.\d+\.\d+`, time.Second*10); err != nil {
+		return nil, err
+	}
+
+	return &Context{modem: modem}, nil
+}
+
+// Shutdown tears down the PDP context (AT+CIPSHUT), releasing the IP it was assigned.
+func (c *Context) Shutdown() error {
+	c.modem.PortMu.Lock()
+	defer c.modem.PortMu.Unlock()
+	_, err := waitResponse(c.modem.Port, "AT+CIPSHUT", "SHUT OK", time.Second*10)
+	return err
+}
+
+var ipdPattern = regexp.MustCompile(`\+IPD,(\d+):`)
+
+// Dial opens a TCP socket to addr ("host:port") over the PDP context and returns it as
+// a net.Conn.
+func (c *Context) Dial(addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &Conn{
+		modem:   c.modem,
+		readBuf: make(chan []byte, 16),
+		closeCh: make(chan struct{}),
+	}
+
+	c.modem.PortMu.Lock()
+	conn.listenerID = c.modem.Port.AddOutputListener(conn.onData)
+	cmd := fmt.Sprintf(`AT+CIPSTART="TCP","%s","%s"`, host, port)
+	response, err := waitResponse(c.modem.Port, cmd, "CONNECT OK|CONNECT FAIL|ALREADY CONNECT", time.Second*30)
+	c.modem.PortMu.Unlock()
+	if err == nil && strings.Contains(response[0], "CONNECT FAIL") {
+		err = errors.New("gprs: " + response[0])
+	}
+	if err != nil {
+		c.modem.Port.DelOutputListener(conn.listenerID)
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Conn is a TCP socket opened over a SIM900 GPRS context. It implements net.Conn.
+type Conn struct {
+	modem      *sim900.SIM900
+	listenerID uint32
+	readBuf    chan []byte
+	leftover   []byte
+	mu         sync.Mutex
+	closeOnce  sync.Once
+	closeCh    chan struct{}
+
+	// pendingBuf/pendingRemaining hold a +IPD record whose payload was split across
+	// onData invocations (the serial driver fragments output on any >20ms read gap), so
+	// it can be completed from the bytes delivered in a later call instead of truncated.
+	pendingBuf       []byte
+	pendingRemaining int
+}
+
+// onData is registered as a serial output listener and extracts the payload carried by
+// any +IPD,<n>: URCs found in b, completing a record left pending by a previous call
+// before looking for new ones.
+func (conn *Conn) onData(b []byte) {
+	conn.mu.Lock()
+	rest := string(b)
+
+	var complete [][]byte
+	if conn.pendingRemaining > 0 {
+		n := conn.pendingRemaining
+		if n > len(rest) {
+			n = len(rest)
+		}
+		conn.pendingBuf = append(conn.pendingBuf, rest[:n]...)
+		conn.pendingRemaining -= n
+		rest = rest[n:]
+		if conn.pendingRemaining == 0 {
+			complete = append(complete, conn.pendingBuf)
+			conn.pendingBuf = nil
+		}
+	}
+
+	for _, m := range ipdPattern.FindAllStringSubmatchIndex(rest, -1) {
+		n, err := strconv.Atoi(rest[m[2]:m[3]])
+		if err != nil {
+			continue
+		}
+		start := m[1]
+		avail := rest[start:]
+		if n > len(avail) {
+			conn.pendingBuf = []byte(avail)
+			conn.pendingRemaining = n - len(avail)
+			break
+		}
+		complete = append(complete, []byte(avail[:n]))
+	}
+	conn.mu.Unlock()
+
+	for _, data := range complete {
+		select {
+		case conn.readBuf <- data:
+		case <-conn.closeCh:
+			return
+		}
+	}
+}
+
+// Read implements net.Conn.
+func (conn *Conn) Read(p []byte) (int, error) {
+	conn.mu.Lock()
+	if len(conn.leftover) > 0 {
+		n := copy(p, conn.leftover)
+		conn.leftover = conn.leftover[n:]
+		conn.mu.Unlock()
+		return n, nil
+	}
+	conn.mu.Unlock()
+
+	select {
+	case data := <-conn.readBuf:
+		n := copy(p, data)
+		if n < len(data) {
+			conn.mu.Lock()
+			conn.leftover = data[n:]
+			conn.mu.Unlock()
+		}
+		return n, nil
+	case <-conn.closeCh:
+		return 0, io.EOF
+	}
+}
+
+// Write implements net.Conn, sending p through the AT+CIPSEND handshake.
+func (conn *Conn) Write(p []byte) (int, error) {
+	conn.modem.PortMu.Lock()
+	defer conn.modem.PortMu.Unlock()
+
+	_, err := waitResponse(conn.modem.Port, "", `(> )`, time.Second*5, func() error {
+		return conn.modem.Port.Print(fmt.Sprintf("AT+CIPSEND=%d\r", len(p)))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := conn.modem.Port.Print(string(p)); err != nil {
+		return 0, err
+	}
+
+	_, err = waitResponse(conn.modem.Port, "", `SEND OK|SEND FAIL`, time.Second*30, func() error {
+		return conn.modem.Port.Print(sim900.CMD_CTRL_Z)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close implements net.Conn, issuing AT+CIPCLOSE.
+func (conn *Conn) Close() error {
+	conn.closeOnce.Do(func() {
+		close(conn.closeCh)
+		conn.modem.Port.DelOutputListener(conn.listenerID)
+	})
+
+	conn.modem.PortMu.Lock()
+	defer conn.modem.PortMu.Unlock()
+	_, err := waitResponse(conn.modem.Port, "AT+CIPCLOSE", sim900.CMD_OK, time.Second*10)
+	return err
+}
+
+func (conn *Conn) LocalAddr() net.Addr                { return nil }
+func (conn *Conn) RemoteAddr() net.Addr               { return nil }
+func (conn *Conn) SetDeadline(t time.Time) error      { return nil }
+func (conn *Conn) SetReadDeadline(t time.Time) error  { return nil }
+func (conn *Conn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,186 @@
+// Package mock provides an in-memory sim900.Transport for testing against a modem
+// without real hardware attached.
+package mock
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/xlab/at/sms"
+)
+
+type rule struct {
+	pattern  *regexp.Regexp
+	response []byte
+}
+
+// Transport is an in-memory sim900.Transport. Commands written by the driver are matched
+// against registered rules; the corresponding canned response is delivered asynchronously
+// to output listeners, the same way URCs arrive from real hardware.
+type Transport struct {
+	mu          sync.Mutex
+	rules       []rule
+	listeners   map[uint32]func([]byte)
+	nextID      uint32
+	unmatchedFn func(cmd string)
+}
+
+// New creates an empty mock Transport. Register expectations with Expect before use.
+func New() *Transport {
+	return &Transport{
+		listeners: map[uint32]func([]byte){},
+	}
+}
+
+// Expect registers a canned response for any command matching pattern. The response is
+// pushed to output listeners as if it arrived from the modem.
+func (m *Transport) Expect(pattern string, response []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, rule{pattern: regexp.MustCompile(pattern), response: response})
+}
+
+// ExpectOK registers an "OK" response for any command matching pattern.
+func (m *Transport) ExpectOK(pattern string) {
+	m.Expect(pattern, []byte("\r\nOK\r\n"))
+}
+
+// Push delivers bs to every registered output listener, simulating an asynchronous URC
+// (+CMT:, RING, +CLIP:, ^CEND:, ...) or any other unsolicited data from the modem.
+func (m *Transport) Push(bs []byte) {
+	m.mu.Lock()
+	listeners := make([]func([]byte), 0, len(m.listeners))
+	for _, fn := range m.listeners {
+		listeners = append(listeners, fn)
+	}
+	m.mu.Unlock()
+	for _, fn := range listeners {
+		go fn(bs)
+	}
+}
+
+// InjectSMS builds a +CMT: PDU notification for an SMS from address with the given text
+// and pushes it to the transport, as if it had just been received over the air.
+func (m *Transport) InjectSMS(address, text string) error {
+	msg := sms.Message{
+		Text:     text,
+		Type:     sms.MessageTypes.Deliver,
+		Encoding: sms.Encodings.Gsm7Bit,
+		Address:  sms.PhoneNumber(address),
+	}
+	if !isASCII(text) {
+		msg.Encoding = sms.Encodings.UCS2
+	}
+	_, octets, err := msg.PDU()
+	if err != nil {
+		return err
+	}
+	m.Push([]byte(fmt.Sprintf("\r\n+CMT: ,0\r\n%X\r\n", octets)))
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Transport) match(cmd string) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rules {
+		if r.pattern.MatchString(cmd) {
+			return r.response
+		}
+	}
+	return nil
+}
+
+// Print sends data through the mock transport, triggering any matching rule's response.
+// Rules are matched against the command with its trailing CR/LF terminator removed.
+func (m *Transport) Print(str string) error {
+	if response := m.match(strings.TrimRight(str, "\r\n")); response != nil {
+		m.Push(response)
+	} else if m.unmatchedFn != nil {
+		m.unmatchedFn(str)
+	}
+	return nil
+}
+
+// Println sends data followed by a CRLF, same as serial.SerialPort.Println.
+func (m *Transport) Println(str string) error {
+	return m.Print(str + "\r\n")
+}
+
+// Printf formats according to a format specifier and sends the result.
+func (m *Transport) Printf(format string, args ...interface{}) error {
+	str := format
+	if len(args) > 0 {
+		str = fmt.Sprintf(format, args...)
+	}
+	return m.Print(str)
+}
+
+// WaitForRegexTimeout waits for a response matching exp, running cmd and inits the same
+// way serial.SerialPort does.
+func (m *Transport) WaitForRegexTimeout(cmd, exp string, timeout time.Duration, inits ...func() error) ([]string, error) {
+	re := regexp.MustCompile(exp)
+	result := make(chan []string, 1)
+	id := m.AddOutputListener(func(bs []byte) {
+		if match := re.FindStringSubmatch(string(bs)); match != nil {
+			select {
+			case result <- match:
+			default:
+			}
+		}
+	})
+	defer m.DelOutputListener(id)
+
+	if cmd != "" {
+		if err := m.Println(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, fn := range inits {
+		if err := fn(); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case match := <-result:
+		return match, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("mock: timeout waiting for %q", exp)
+	}
+}
+
+// AddOutputListener registers fn to receive every response pushed through the transport.
+func (m *Transport) AddOutputListener(fn func([]byte)) uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	id := m.nextID
+	m.listeners[id] = fn
+	return id
+}
+
+// DelOutputListener removes a previously registered listener.
+func (m *Transport) DelOutputListener(id uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.listeners, id)
+}
+
+// Close is a no-op for the mock transport.
+func (m *Transport) Close() error {
+	return nil
+}
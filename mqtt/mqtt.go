@@ -0,0 +1,429 @@
+// Package mqtt is a minimal MQTT v3.1.1 client (CONNECT/PUBLISH/SUBSCRIBE/PINGREQ),
+// enough to publish IoT telemetry over a sim900/gprs socket. It reconnects with
+// exponential backoff whenever the underlying connection drops.
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	pktConnect    byte = 1 << 4
+	pktConnAck    byte = 2 << 4
+	pktPublish    byte = 3 << 4
+	pktPubAck     byte = 4 << 4
+	pktSubscribe  byte = 8 << 4
+	pktSubAck     byte = 9 << 4
+	pktPingReq    byte = 12 << 4
+	pktPingResp   byte = 13 << 4
+	pktDisconnect byte = 14 << 4
+)
+
+// Dialer creates the underlying transport for a new (re)connection attempt.
+type Dialer func() (net.Conn, error)
+
+// Options configures a Client.
+type Options struct {
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration // defaults to 60s
+}
+
+// Client is a minimal MQTT v3.1.1 client built to publish telemetry from IoT trackers
+// over a modem socket. It is safe for concurrent use.
+type Client struct {
+	dial       Dialer
+	opts       Options
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// Teardown, if set, is called by Close after the MQTT session ends, e.g. to issue
+	// AT+CIPCLOSE/AT+CIPSHUT before the serial port is released.
+	Teardown func() error
+
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+	closeCh chan struct{}
+	nextID  uint32
+	subs    map[string]subscription
+}
+
+// subscription is a topic's registered handler and the QoS it was subscribed with, kept
+// so a reconnect can resend an identical SUBSCRIBE.
+type subscription struct {
+	qos       byte
+	onMessage func(topic string, payload []byte)
+}
+
+// New creates a Client that dials new connections via dial. Call Connect to establish
+// the first connection and start the background reconnect/keepalive loop.
+func New(dial Dialer, opts Options) *Client {
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 60 * time.Second
+	}
+	return &Client{
+		dial:       dial,
+		opts:       opts,
+		backoff:    time.Second,
+		maxBackoff: time.Minute,
+		closeCh:    make(chan struct{}),
+		subs:       map[string]subscription{},
+	}
+}
+
+// Connect dials and performs the initial MQTT CONNECT handshake, then starts the
+// background loop that keeps the session alive and reconnects on failure.
+func (c *Client) Connect() error {
+	conn, err := c.connectOnce()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	go c.run(conn)
+	return nil
+}
+
+// connectOnce dials and sends CONNECT, waiting for a successful CONNACK.
+func (c *Client) connectOnce() (net.Conn, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeConnect(conn, c.opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	kind, body, err := readPacket(bufio.NewReader(conn))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if kind&0xF0 != pktConnAck || len(body) < 2 || body[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: CONNECT refused (packet %x, body %v)", kind, body)
+	}
+
+	return conn, nil
+}
+
+// run owns conn until it fails, dispatching PUBLISH packets to subscribers and sending
+// periodic PINGREQ, then reconnects with exponential backoff unless the client is closed.
+func (c *Client) run(conn net.Conn) {
+	for {
+		err := c.serve(conn)
+		if c.isClosed() {
+			return
+		}
+		if err != nil {
+			time.Sleep(c.backoff)
+			c.backoff *= 2
+			if c.backoff > c.maxBackoff {
+				c.backoff = c.maxBackoff
+			}
+		}
+
+		newConn, err := c.connectOnce()
+		if err != nil {
+			continue
+		}
+		c.backoff = time.Second
+		c.mu.Lock()
+		c.conn = newConn
+		c.mu.Unlock()
+		conn = newConn
+		c.resubscribe(conn)
+	}
+}
+
+// resubscribe re-sends SUBSCRIBE for every topic in c.subs after a reconnect. writeConnect
+// always starts a clean session, so the broker has forgotten them.
+func (c *Client) resubscribe(conn net.Conn) {
+	c.mu.Lock()
+	subs := make(map[string]subscription, len(c.subs))
+	for topic, sub := range c.subs {
+		subs[topic] = sub
+	}
+	c.mu.Unlock()
+
+	for topic, sub := range subs {
+		pktID := uint16(atomic.AddUint32(&c.nextID, 1))
+		pkt := encodeSubscribe(topic, sub.qos, pktID)
+		c.mu.Lock()
+		conn.Write(pkt)
+		c.mu.Unlock()
+	}
+}
+
+// serve reads packets off conn and replies to keepalive pings until it errors or closes.
+func (c *Client) serve(conn net.Conn) error {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	pingTicker := time.NewTicker(c.opts.KeepAlive / 2)
+	defer pingTicker.Stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			kind, body, err := readPacket(reader)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if kind&0xF0 == pktPublish {
+				c.dispatchPublish(kind, body)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-c.closeCh:
+			return nil
+		case err := <-errCh:
+			return err
+		case <-pingTicker.C:
+			c.mu.Lock()
+			_, err := conn.Write([]byte{pktPingReq, 0})
+			c.mu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Publish sends a PUBLISH packet. Only QoS 0 (fire-and-forget) and QoS 1 (acknowledged,
+// without resend on drop) are supported, matching the minimal scope of this client.
+func (c *Client) Publish(topic string, payload []byte, qos byte) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return errors.New("mqtt: not connected")
+	}
+
+	var pktID uint16
+	if qos > 0 {
+		pktID = uint16(atomic.AddUint32(&c.nextID, 1))
+	}
+
+	pkt := encodePublish(topic, payload, qos, pktID)
+	c.mu.Lock()
+	_, err := conn.Write(pkt)
+	c.mu.Unlock()
+	return err
+}
+
+// Subscribe sends a SUBSCRIBE packet for topic and registers onMessage to receive any
+// PUBLISH that matches it. Wildcards are matched literally, not expanded.
+func (c *Client) Subscribe(topic string, qos byte, onMessage func(topic string, payload []byte)) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.subs[topic] = subscription{qos: qos, onMessage: onMessage}
+	c.mu.Unlock()
+	if conn == nil {
+		return errors.New("mqtt: not connected")
+	}
+
+	pktID := uint16(atomic.AddUint32(&c.nextID, 1))
+	pkt := encodeSubscribe(topic, qos, pktID)
+	c.mu.Lock()
+	_, err := conn.Write(pkt)
+	c.mu.Unlock()
+	return err
+}
+
+func (c *Client) dispatchPublish(flags byte, body []byte) {
+	topic, payload, ok := decodePublish(flags, body)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	sub, ok := c.subs[topic]
+	c.mu.Unlock()
+	if ok && sub.onMessage != nil {
+		go sub.onMessage(topic, payload)
+	}
+}
+
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close sends DISCONNECT, stops the reconnect loop and runs Teardown, if set, so callers
+// can release the modem (AT+CIPCLOSE/AT+CIPSHUT) before giving up the serial port.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	conn := c.conn
+	c.mu.Unlock()
+
+	close(c.closeCh)
+	if conn != nil {
+		conn.Write([]byte{pktDisconnect, 0})
+		conn.Close()
+	}
+
+	if c.Teardown != nil {
+		return c.Teardown()
+	}
+	return nil
+}
+
+// encodeRemainingLength encodes n using the MQTT variable-length scheme.
+func encodeRemainingLength(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// readRemainingLength decodes the MQTT variable-length scheme from r.
+func readRemainingLength(r *bufio.Reader) (int, error) {
+	multiplier := 1
+	value := 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			return value, nil
+		}
+		multiplier *= 128
+	}
+}
+
+// readPacket reads one MQTT control packet from r, returning its fixed-header byte and
+// variable-header+payload bytes.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := readRemainingLength(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return kind, body, nil
+}
+
+func encodeString(s string) []byte {
+	out := make([]byte, 2+len(s))
+	binary.BigEndian.PutUint16(out, uint16(len(s)))
+	copy(out[2:], s)
+	return out
+}
+
+func writeConnect(conn net.Conn, opts Options) error {
+	var flags byte = 0x02 // clean session
+	var payload []byte
+
+	payload = append(payload, encodeString(opts.ClientID)...)
+
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = append(payload, encodeString(opts.Username)...)
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+		payload = append(payload, encodeString(opts.Password)...)
+	}
+
+	var header []byte
+	header = append(header, encodeString("MQTT")...)
+	header = append(header, 4) // protocol level 4 = v3.1.1
+	header = append(header, flags)
+	keepAlive := uint16(opts.KeepAlive / time.Second)
+	header = append(header, byte(keepAlive>>8), byte(keepAlive))
+
+	body := append(header, payload...)
+	pkt := append([]byte{pktConnect}, encodeRemainingLength(len(body))...)
+	pkt = append(pkt, body...)
+
+	_, err := conn.Write(pkt)
+	return err
+}
+
+func encodePublish(topic string, payload []byte, qos byte, pktID uint16) []byte {
+	var body []byte
+	body = append(body, encodeString(topic)...)
+	if qos > 0 {
+		body = append(body, byte(pktID>>8), byte(pktID))
+	}
+	body = append(body, payload...)
+
+	flags := pktPublish | (qos << 1)
+	pkt := append([]byte{flags}, encodeRemainingLength(len(body))...)
+	return append(pkt, body...)
+}
+
+func decodePublish(flags byte, body []byte) (topic string, payload []byte, ok bool) {
+	if len(body) < 2 {
+		return "", nil, false
+	}
+	topicLen := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+topicLen {
+		return "", nil, false
+	}
+	topic = string(body[2 : 2+topicLen])
+	rest := body[2+topicLen:]
+
+	qos := (flags >> 1) & 0x03
+	if qos > 0 {
+		if len(rest) < 2 {
+			return "", nil, false
+		}
+		rest = rest[2:]
+	}
+	return topic, rest, true
+}
+
+func encodeSubscribe(topic string, qos byte, pktID uint16) []byte {
+	var body []byte
+	body = append(body, byte(pktID>>8), byte(pktID))
+	body = append(body, encodeString(topic)...)
+	body = append(body, qos)
+
+	pkt := append([]byte{pktSubscribe | 0x02}, encodeRemainingLength(len(body))...)
+	return append(pkt, body...)
+}
@@ -0,0 +1,108 @@
+package sim900
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetworkInfo describes the modem's current SIM, registration and operator state.
+type NetworkInfo struct {
+	PIN           string // AT+CPIN? status, e.g. "READY", "SIM PIN"
+	RegStatus     int    // AT+CREG? <stat>: 0 not registered, 1 home, 2 searching, 3 denied, 4 unknown, 5 roaming
+	LAC           string // location area code, hex, only set when AT+CREG=2
+	CI            string // cell ID, hex, only set when AT+CREG=2
+	GPRSRegStatus int    // AT+CGREG? <stat>, same meaning as RegStatus
+	Operator      string // AT+COPS? operator name
+	AccessTech    int    // AT+COPS? <AcT>: 0 GSM, 2 UTRAN, 3 GSM/EGPRS, ...
+	IMSI          string // AT+CIMI
+	IMEI          string // AT+GSN
+}
+
+// Registered reports whether RegStatus is either registered-home or registered-roaming.
+func (n *NetworkInfo) Registered() bool {
+	return n.RegStatus == 1 || n.RegStatus == 5
+}
+
+// NetworkInfo queries SIM PIN state, registration status, LAC/CI, operator and
+// IMSI/IMEI, so callers can diagnose why SMS/calls fail before attempting them.
+func (s *SIM900) NetworkInfo() (*NetworkInfo, error) {
+	info := &NetworkInfo{}
+
+	response, err := s.Wait4response("AT+CPIN?", `\+CPIN:\s*([A-Z ]+)`, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	info.PIN = strings.TrimSpace(response[1])
+
+	response, err = s.Wait4response("AT+CREG?", `\+CREG:\s*\d+,(\d+)(?:,"([0-9A-Fa-f]+)","([0-9A-Fa-f]+)")?`, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	info.RegStatus, _ = strconv.Atoi(response[1])
+	info.LAC = response[2]
+	info.CI = response[3]
+
+	response, err = s.Wait4response("AT+CGREG?", `\+CGREG:\s*\d+,(\d+)`, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	info.GPRSRegStatus, _ = strconv.Atoi(response[1])
+
+	response, err = s.Wait4response("AT+COPS?", `\+COPS:\s*\d+,\d+,"([^"]+)"(?:,(\d+))?`, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	info.Operator = response[1]
+	info.AccessTech, _ = strconv.Atoi(response[2])
+
+	response, err = s.Wait4response("AT+CIMI", `(\d{10,15})`, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	info.IMSI = response[1]
+
+	response, err = s.Wait4response("AT+GSN", `(\d{10,15})`, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	info.IMEI = response[1]
+
+	return info, nil
+}
+
+var cregURCPattern = `\+CREG:\s*(\d+)(?:,"([0-9A-Fa-f]+)","([0-9A-Fa-f]+)")?`
+
+// WaitForRegistration blocks until the modem reports registered-home or
+// registered-roaming, checking the current status first and then waiting on the
+// asynchronous +CREG: URC (enabled via AT+CREG=2 in Init) for any later change.
+func (s *SIM900) WaitForRegistration(timeout time.Duration) error {
+	if info, err := s.NetworkInfo(); err == nil && info.Registered() {
+		return nil
+	}
+
+	re := regexp.MustCompile(cregURCPattern)
+	result := make(chan struct{}, 1)
+	defer s.Port.DelOutputListener(s.Port.AddOutputListener(func(b []byte) {
+		match := re.FindStringSubmatch(string(b))
+		if match == nil {
+			return
+		}
+		stat, _ := strconv.Atoi(match[1])
+		if stat == 1 || stat == 5 {
+			select {
+			case result <- struct{}{}:
+			default:
+			}
+		}
+	}))
+
+	select {
+	case <-result:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("sim900: timeout waiting for network registration")
+	}
+}
@@ -0,0 +1,264 @@
+package sim900
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QueueState is the lifecycle state of a QueuedMessage.
+type QueueState int
+
+// Queue states, in the order a message normally progresses through them.
+const (
+	StatePending QueueState = iota
+	StateSubmitted
+	StateDelivered
+	StateFailed
+)
+
+func (st QueueState) String() string {
+	switch st {
+	case StatePending:
+		return "pending"
+	case StateSubmitted:
+		return "submitted"
+	case StateDelivered:
+		return "delivered"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// QueuedMessage is one outgoing SMS tracked by a Queue.
+type QueuedMessage struct {
+	ID       string
+	Address  string
+	Text     string
+	State    QueueState
+	SMSCID   string // numeric id returned by +CMGS:, correlates +CDS: reports
+	Attempts int
+
+	// resumedFromStore marks a StateSubmitted message loaded by resume() after a process
+	// restart, so only it gets a DeliveryTimeout/resubmit: a live submission's +CDS: report
+	// may simply be slow or disabled by the carrier, not lost, and must not be resent.
+	resumedFromStore bool
+}
+
+// Store persists QueuedMessages so a Queue can resume pending/submitted messages across
+// process restarts without losing them. A BoltDB-backed implementation or any other
+// storage can satisfy this interface.
+type Store interface {
+	Save(msg *QueuedMessage) error
+	Delete(id string) error
+	List() ([]*QueuedMessage, error)
+}
+
+// Queue wraps SendSMS with persistence, retry and delivery-report correlation, mirroring
+// how larger SMS gateways manage submissions.
+type Queue struct {
+	modem      *SIM900
+	store      Store
+	nextID     uint64
+	listenerID uint64
+
+	// Backoff/MaxBackoff control the retry delay for messages that fail to submit.
+	// They default to 5s and 5m and double on every failed attempt, capped at MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// MaxAttempts caps how many times submit retries a message before giving up and
+	// marking it StateFailed. It defaults to 10. The same cap applies to a StateSubmitted
+	// message resumed from the Store that never receives a +CDS: report.
+	MaxAttempts int
+
+	// DeliveryTimeout bounds how long a resumed StateSubmitted message waits for a +CDS:
+	// report before it is resubmitted. It defaults to 10 minutes.
+	DeliveryTimeout time.Duration
+
+	// OnStateChange, if set, is called whenever a queued message changes state.
+	OnStateChange func(msg *QueuedMessage)
+
+	mu       sync.Mutex
+	bySMSCID map[string]*QueuedMessage
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewQueue creates a Queue backed by store, resuming any pending or submitted messages
+// found in it (pending messages are retried, submitted ones are re-tracked for delivery
+// reports).
+func NewQueue(modem *SIM900, store Store) *Queue {
+	q := &Queue{
+		modem:           modem,
+		store:           store,
+		Backoff:         5 * time.Second,
+		MaxBackoff:      5 * time.Minute,
+		MaxAttempts:     10,
+		DeliveryTimeout: 10 * time.Minute,
+		bySMSCID:        map[string]*QueuedMessage{},
+		stopCh:          make(chan struct{}),
+	}
+	q.listenerID = modem.AddDeliveryReportListener(q.onDeliveryReport)
+	q.resume()
+	return q
+}
+
+// Close stops listening for delivery reports and signals any in-flight submit/resume
+// retries to stop. Queued messages already persisted in the Store remain there so a new
+// Queue over the same Store can resume them.
+func (q *Queue) Close() {
+	q.modem.DelDeliveryReportListener(q.listenerID)
+	q.closeOnce.Do(func() {
+		close(q.stopCh)
+	})
+}
+
+func (q *Queue) resume() {
+	msgs, err := q.store.List()
+	if err != nil {
+		return
+	}
+	for _, msg := range msgs {
+		switch msg.State {
+		case StateSubmitted:
+			msg.resumedFromStore = true
+			q.mu.Lock()
+			q.bySMSCID[msg.SMSCID] = msg
+			q.mu.Unlock()
+			go q.awaitDelivery(msg)
+		case StatePending:
+			go q.submit(msg)
+		}
+	}
+}
+
+// awaitDelivery waits up to DeliveryTimeout for a resumed StateSubmitted message's +CDS:
+// report to arrive via onDeliveryReport. If it times out, the report was most likely lost
+// in the restart that interrupted the process, so the message is dropped from tracking and
+// resubmitted via submit rather than waiting forever. Only called for messages resume()
+// loaded from the Store; a live submission's report may just be slow or carrier-disabled.
+func (q *Queue) awaitDelivery(msg *QueuedMessage) {
+	timer := time.NewTimer(q.DeliveryTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-q.stopCh:
+		return
+	case <-timer.C:
+	}
+
+	q.mu.Lock()
+	_, stillWaiting := q.bySMSCID[msg.SMSCID]
+	if stillWaiting {
+		delete(q.bySMSCID, msg.SMSCID)
+	}
+	q.mu.Unlock()
+	if !stillWaiting {
+		return
+	}
+
+	if msg.Attempts >= q.MaxAttempts {
+		msg.State = StateFailed
+		q.store.Save(msg)
+		q.notify(msg)
+		return
+	}
+
+	msg.State = StatePending
+	q.store.Save(msg)
+	q.submit(msg)
+}
+
+// Enqueue persists text addressed to addr as a pending message and submits it in the
+// background, retrying with exponential backoff on failure. It returns the queue-local
+// id immediately; use OnStateChange to observe progress to submitted/delivered/failed.
+func (q *Queue) Enqueue(addr, text string) (string, error) {
+	id := q.newID()
+	msg := &QueuedMessage{
+		ID:      id,
+		Address: addr,
+		Text:    text,
+		State:   StatePending,
+	}
+	if err := q.store.Save(msg); err != nil {
+		return "", err
+	}
+	go q.submit(msg)
+	return id, nil
+}
+
+func (q *Queue) newID() string {
+	n := atomic.AddUint64(&q.nextID, 1)
+	return time.Now().UTC().Format("20060102150405") + "-" + strconv.FormatUint(n, 10)
+}
+
+// submit retries SendSMS with exponential backoff until it succeeds, MaxAttempts is
+// reached (in which case msg is marked StateFailed), or the Queue is closed.
+func (q *Queue) submit(msg *QueuedMessage) {
+	backoff := q.Backoff
+	for {
+		smscID, err := q.modem.SendSMS(msg.Address, msg.Text)
+		msg.Attempts++
+		if err == nil {
+			msg.State = StateSubmitted
+			msg.SMSCID = smscID
+			q.store.Save(msg)
+			q.mu.Lock()
+			q.bySMSCID[smscID] = msg
+			q.mu.Unlock()
+			q.notify(msg)
+			return
+		}
+
+		if msg.Attempts >= q.MaxAttempts {
+			msg.State = StateFailed
+			q.store.Save(msg)
+			q.notify(msg)
+			return
+		}
+
+		q.store.Save(msg)
+		select {
+		case <-q.stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > q.MaxBackoff {
+			backoff = q.MaxBackoff
+		}
+	}
+}
+
+// onDeliveryReport advances a submitted message to delivered/failed once its +CDS:
+// status report arrives.
+func (q *Queue) onDeliveryReport(smscID string, delivered bool) {
+	q.mu.Lock()
+	msg, ok := q.bySMSCID[smscID]
+	if ok {
+		delete(q.bySMSCID, smscID)
+	}
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if delivered {
+		msg.State = StateDelivered
+	} else {
+		msg.State = StateFailed
+	}
+	q.store.Save(msg)
+	q.notify(msg)
+}
+
+func (q *Queue) notify(msg *QueuedMessage) {
+	if q.OnStateChange != nil {
+		go q.OnStateChange(msg)
+	}
+}
@@ -0,0 +1,69 @@
+package sim900_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vinhjaxt/sim900"
+)
+
+// memStore is a minimal in-memory sim900.Store for tests.
+type memStore struct {
+	mu   sync.Mutex
+	msgs map[string]*sim900.QueuedMessage
+}
+
+func newMemStore() *memStore {
+	return &memStore{msgs: map[string]*sim900.QueuedMessage{}}
+}
+
+func (s *memStore) Save(msg *sim900.QueuedMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *msg
+	s.msgs[msg.ID] = &cp
+	return nil
+}
+
+func (s *memStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.msgs, id)
+	return nil
+}
+
+func (s *memStore) List() ([]*sim900.QueuedMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*sim900.QueuedMessage, 0, len(s.msgs))
+	for _, msg := range s.msgs {
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+func TestQueueSubmitCapsRetriesAndFails(t *testing.T) {
+	ss, tr := newTestModem(t)
+	tr.Expect(`AT\+CMGS=\d+`, []byte("\r\n+CMS ERROR: 500\r\n"))
+
+	q := sim900.NewQueue(ss, newMemStore())
+	defer q.Close()
+	q.MaxAttempts = 1
+
+	changes := make(chan *sim900.QueuedMessage, 4)
+	q.OnStateChange = func(msg *sim900.QueuedMessage) { changes <- msg }
+
+	if _, err := q.Enqueue("+84902107790", "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-changes:
+		if msg.State != sim900.StateFailed {
+			t.Fatalf("expected StateFailed after MaxAttempts, got %v", msg.State)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for OnStateChange")
+	}
+}
@@ -0,0 +1,73 @@
+package sim900
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var csqPattern = regexp.MustCompile(`\+CSQ:\s*(\d+),(\d+)`)
+
+// SignalQuality issues AT+CSQ and returns the raw RSSI index, its dBm equivalent, a
+// qualitative condition label (see the table at the top of sim900.go) and the bit error rate. rssi/ber of
+// 99 mean "unknown" per the AT+CSQ spec and are returned as-is.
+func (s *SIM900) SignalQuality() (rssi int, dbm int, condition string, ber int, err error) {
+	response, err := s.Wait4response("AT+CSQ", csqPattern.String(), time.Second*5)
+	if err != nil {
+		return 0, 0, "", 0, err
+	}
+	match := csqPattern.FindStringSubmatch(response[0])
+	if match == nil {
+		return 0, 0, "", 0, errors.New("sim900: malformed AT+CSQ response: " + response[0])
+	}
+	fmt.Sscanf(match[1], "%d", &rssi)
+	fmt.Sscanf(match[2], "%d", &ber)
+
+	if rssi == 99 {
+		return rssi, 0, "Unknown", ber, nil
+	}
+	dbm = rssi*2 - 113
+	return rssi, dbm, signalCondition(rssi), ber, nil
+}
+
+// signalCondition maps a raw AT+CSQ RSSI index to the qualitative label from the table at the
+// top of sim900.go.
+func signalCondition(rssi int) string {
+	switch {
+	case rssi >= 20:
+		return "Excellent"
+	case rssi >= 15:
+		return "Good"
+	case rssi >= 10:
+		return "OK"
+	case rssi >= 2:
+		return "Marginal"
+	default:
+		return "No signal"
+	}
+}
+
+// StartSignalMonitor polls SignalQuality every interval in a background goroutine,
+// invoking cb with each reading. Polling stops once Close is called.
+func (s *SIM900) StartSignalMonitor(interval time.Duration, cb func(rssi, dbm int, cond string)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				rssi, dbm, cond, _, err := s.SignalQuality()
+				if err != nil {
+					if s.OnError != nil {
+						s.OnError(err)
+					}
+					continue
+				}
+				cb(rssi, dbm, cond)
+			}
+		}
+	}()
+}
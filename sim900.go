@@ -40,6 +40,7 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -54,30 +55,68 @@ import (
 // A SIM900 is the representation of a SIM900 GSM modem with several utility features.
 type SIM900 struct {
 	nextSMSEvent uint64 // move to the first field fix 64bit unaligned pointers atomic
+	smsRef       uint32 // concatenated SMS reference counter, atomic
 	PortMu       *sync.RWMutex
-	Port         *serial.SerialPort
+	Port         Transport
 	logger       *log.Logger
 	CSCA         string
 	SMSEventLock *sync.RWMutex
 	mapSMSEvents map[uint64]func(sms *sms.Message)
-	OnNewCall    func(phoneNumber string)
-	OnError      func(err error)
+
+	nextDeliveryEvent uint64
+	DeliveryEventLock *sync.RWMutex
+	mapDeliveryEvents map[uint64]func(id string, delivered bool)
+
+	concatMu    sync.Mutex
+	concatParts map[concatKey]map[byte]*sms.Message
+
+	nextCallEvent uint64
+	CallEventLock *sync.RWMutex
+	mapCallEvents map[uint64]func(*IncomingCall)
+
+	closeOnce sync.Once
+	stopCh    chan struct{}
+	OnError   func(err error)
+}
+
+// concatKey identifies the fragments of a single concatenated (long) SMS while they are reassembled.
+type concatKey struct {
+	address string
+	ref     byte
 }
 
 // New creates and initializes a new SIM900 device.
 func New() *SIM900 {
 	return &SIM900{
-		Port:         serial.New(),
-		PortMu:       &sync.RWMutex{},
-		SMSEventLock: &sync.RWMutex{},
-		logger:       log.New(os.Stdout, "[sim900] ", log.LstdFlags),
-		mapSMSEvents: map[uint64]func(*sms.Message){},
+		Port:              serial.New(),
+		PortMu:            &sync.RWMutex{},
+		SMSEventLock:      &sync.RWMutex{},
+		logger:            log.New(os.Stdout, "[sim900] ", log.LstdFlags),
+		mapSMSEvents:      map[uint64]func(*sms.Message){},
+		DeliveryEventLock: &sync.RWMutex{},
+		mapDeliveryEvents: map[uint64]func(id string, delivered bool){},
+		concatParts:       map[concatKey]map[byte]*sms.Message{},
+		CallEventLock:     &sync.RWMutex{},
+		mapCallEvents:     map[uint64]func(*IncomingCall){},
+		stopCh:            make(chan struct{}),
 	}
 }
 
+// NewWithTransport creates a SIM900 bound to an already-usable Transport, such as the
+// mock backend in sim900/mock, skipping Connect/Open. Callers still need to run Init.
+func NewWithTransport(t Transport) *SIM900 {
+	s := New()
+	s.Port = t
+	return s
+}
+
 // Connect creates a connection with the SIM900 modem via serial port and test communications.
 func (s *SIM900) Connect(port string, baud int) error {
-	if err := s.Port.Open(port, baud, time.Millisecond*100); err != nil {
+	sp, ok := s.Port.(*serial.SerialPort)
+	if !ok {
+		return errors.New("Connect requires a *serial.SerialPort transport")
+	}
+	if err := sp.Open(port, baud, time.Millisecond*100); err != nil {
 		return err
 	}
 	return s.Init()
@@ -85,6 +124,9 @@ func (s *SIM900) Connect(port string, baud int) error {
 
 // Close device serial port
 func (s *SIM900) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+	})
 	return s.Port.Close()
 }
 
@@ -139,10 +181,6 @@ func (s *SIM900) SendSMS(address, text string) (string, error) {
 		return "", errors.New("SMS Length > 160")
 	}
 
-	s.PortMu.Lock()
-	defer s.PortMu.Unlock()
-	time.Sleep(5 * time.Second)
-
 	msg := sms.Message{
 		Text:                text,
 		Type:                sms.MessageTypes.Submit,
@@ -162,12 +200,115 @@ func (s *SIM900) SendSMS(address, text string) (string, error) {
 		msg.Encoding = sms.Encodings.UCS2
 	}
 
+	return s.sendMessage(msg)
+}
+
+// Maximum text length per concatenated SMS part, leaving room for the 6-byte UDH.
+const (
+	gsm7PartChars = 153
+	ucs2PartChars = 67
+)
+
+// SendLongSMS sends text of any length, transparently splitting it into multiple
+// concatenated (UDH) parts when it doesn't fit in a single SMS. It returns the
+// message ID of every submitted part, in order, so callers can correlate delivery
+// reports. On a single-part failure it aborts and returns the IDs already
+// submitted together with the error.
+func (s *SIM900) SendLongSMS(address, text string) ([]string, error) {
+	encoding := sms.Encodings.Gsm7Bit
+	singleChars, partChars := 160, gsm7PartChars
+	if !isASCII(text) {
+		encoding = sms.Encodings.UCS2
+		singleChars, partChars = 70, ucs2PartChars
+	}
+
+	parts := []string{text}
+	if len([]rune(text)) > singleChars {
+		parts = splitSMSText(text, partChars)
+	}
+	if len(parts) == 1 {
+		// Built directly against sendMessage, not SendSMS: SendSMS's length check counts
+		// raw UTF-8 bytes, which rejects UCS2 text well under ucs2PartChars runes.
+		msg := sms.Message{
+			Text:                text,
+			Type:                sms.MessageTypes.Submit,
+			Encoding:            encoding,
+			Address:             sms.PhoneNumber(address),
+			VPFormat:            sms.ValidityPeriodFormats.Relative,
+			VP:                  sms.ValidityPeriod(63 * 7 * 24 * time.Hour),
+			RejectDuplicates:    true,
+			StatusReportRequest: true,
+		}
+		if s.CSCA != "" {
+			msg.ServiceCenterAddress = sms.PhoneNumber(s.CSCA)
+		}
+
+		id, err := s.sendMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		return []string{id}, nil
+	}
+
+	ref := byte(atomic.AddUint32(&s.smsRef, 1))
+	total := byte(len(parts))
+	ids := make([]string, 0, len(parts))
+	for i, part := range parts {
+		msg := sms.Message{
+			Text:                part,
+			Type:                sms.MessageTypes.Submit,
+			Encoding:            encoding,
+			Address:             sms.PhoneNumber(address),
+			VPFormat:            sms.ValidityPeriodFormats.Relative,
+			VP:                  sms.ValidityPeriod(63 * 7 * 24 * time.Hour),
+			RejectDuplicates:    true,
+			StatusReportRequest: true,
+			UDH:                 []byte{0x00, 0x03, ref, total, byte(i + 1)},
+		}
+
+		if s.CSCA != "" {
+			msg.ServiceCenterAddress = sms.PhoneNumber(s.CSCA)
+		}
+
+		id, err := s.sendMessage(msg)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// splitSMSText splits text into chunks of at most partChars runes each.
+func splitSMSText(text string, partChars int) []string {
+	runes := []rune(text)
+	if len(runes) <= partChars {
+		return []string{text}
+	}
+	parts := make([]string, 0, (len(runes)+partChars-1)/partChars)
+	for len(runes) > 0 {
+		n := partChars
+		if n > len(runes) {
+			n = len(runes)
+		}
+		parts = append(parts, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return parts
+}
+
+// sendMessage encodes msg to a PDU and submits it through the AT+CMGS handshake, returning the SMSC-assigned message ID.
+func (s *SIM900) sendMessage(msg sms.Message) (string, error) {
+	s.PortMu.Lock()
+	defer s.PortMu.Unlock()
+	time.Sleep(5 * time.Second)
+
 	n, octets, err := msg.PDU()
 	if err != nil {
 		return "", err
 	}
 
-	response, err := s.wait4response("", `(> )|(\+CMS ERROR: \d+($|\W))`, time.Second*3, func() error {
+	_, err = s.wait4response("", `(> )|(\+CMS ERROR: \d+($|\W))`, time.Second*3, func() error {
 		return s.Port.Print(fmt.Sprintf("AT+CMGS=%d\r", n))
 	})
 	if err != nil {
@@ -181,7 +322,7 @@ func (s *SIM900) SendSMS(address, text string) (string, error) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	response, err = s.wait4response("", `(\+CMGS: (\d+)($|\W))|(\+CMS ERROR: \d+($|\W))`, time.Second*60, func() error {
+	response, err := s.wait4response("", `(\+CMGS: (\d+)($|\W))|(\+CMS ERROR: \d+($|\W))`, time.Second*60, func() error {
 		return s.Port.Print(CMD_CTRL_Z)
 	})
 	if err != nil {
@@ -206,34 +347,6 @@ func (s *SIM900) AddSMSListener(fn func(*sms.Message)) uint64 {
 	return id
 }
 
-// Call to phone number
-func (s *SIM900) Call(phoneNumber string, timeout time.Duration) (string, error) {
-	s.PortMu.Lock()
-	defer s.PortMu.Unlock()
-
-	time.Sleep(1 * time.Second)
-	re := regexp.MustCompile(`\^CEND:[,\d]+[^,\d]`)
-
-	result := make(chan string, 1)
-	defer s.Port.DelOutputListener(s.Port.AddOutputListener(func(bs []byte) {
-		m := re.FindSubmatch(bs)
-		if len(m) > 0 {
-			result <- string(m[0])
-		}
-	}))
-	err := s.Port.Println("ATD" + phoneNumber + ";")
-	if err != nil {
-		return "", err
-	}
-
-	select {
-	case ret := <-result:
-		return ret, nil
-	case <-time.After(timeout):
-		return "", errors.New("Wait call timeout")
-	}
-}
-
 // WaitSMSText wait for sms match by phone number
 func (s *SIM900) WaitSMSText(phoneNumber string, timeout time.Duration, inits ...func() error) (string, error) {
 	result := make(chan string, 1)
@@ -287,7 +400,6 @@ func (s *SIM900) Init() error {
 
 	newMessagePattern := regexp.MustCompile(`\+CMT:[\s,\d]+\r?\n([a-zA-Z\d]+)(\r?\n|$)`)
 	newCallPattern := regexp.MustCompile(`(^|\W)RING(\r?\n)+\+CLIP: "(\d+)"($|\W)`)
-	endCallPattern := regexp.MustCompile(`\^CEND:\d+`)
 	isRinging := atomic.Value{}
 	isRinging.Store(false)
 
@@ -326,6 +438,11 @@ func (s *SIM900) Init() error {
 					continue
 				}
 				log.Println("Got message from:", msg.Address)
+				msg = s.reassemble(msg)
+				if msg == nil {
+					// Part of a concatenated SMS, waiting for the remaining parts.
+					continue
+				}
 				go func() {
 					s.SMSEventLock.RLock()
 					for _, fn := range s.mapSMSEvents {
@@ -336,6 +453,32 @@ func (s *SIM900) Init() error {
 			}
 		}
 
+		cdsMatches := cdsPattern.FindAllStringSubmatch(body, -1)
+		for _, match := range cdsMatches {
+			if len(match) == 0 {
+				continue
+			}
+			bs, err := hex.DecodeString(strings.Trim(match[1], "\r\n"))
+			if err != nil {
+				if s.OnError != nil {
+					s.OnError(err)
+				}
+				continue
+			}
+			ref, delivered, ok := parseCDS(bs)
+			if !ok {
+				continue
+			}
+			id := strconv.Itoa(int(ref))
+			go func() {
+				s.DeliveryEventLock.RLock()
+				for _, fn := range s.mapDeliveryEvents {
+					go fn(id, delivered)
+				}
+				s.DeliveryEventLock.RUnlock()
+			}()
+		}
+
 		if isRinging.Load().(bool) && endCallPattern.MatchString(body) {
 			isRinging.Store(false)
 		}
@@ -345,9 +488,14 @@ func (s *SIM900) Init() error {
 			if len(match) > 0 {
 				// có cuộc gọi đến
 				isRinging.Store(true)
-				if s.OnNewCall != nil {
-					go s.OnNewCall(match[3])
-				}
+				ic := &IncomingCall{modem: s, PhoneNumber: match[3]}
+				go func() {
+					s.CallEventLock.RLock()
+					for _, fn := range s.mapCallEvents {
+						go fn(ic)
+					}
+					s.CallEventLock.RUnlock()
+				}()
 			}
 		}
 	})
@@ -401,8 +549,8 @@ func (s *SIM900) Init() error {
 		return err
 	}
 
-	// Dont store sms, return pdu data
-	_, err = s.Wait4response("AT+CNMI=1,2,0,0,0", CMD_OK, time.Second*5)
+	// Dont store sms, return pdu data; ds=1 routes SMS-STATUS-REPORTs directly as +CDS: PDUs
+	_, err = s.Wait4response("AT+CNMI=1,2,0,1,0", CMD_OK, time.Second*5)
 	// _, err = s.Wait4response("AT+CNMI=1,2,2,2,0", CMD_OK, time.Second*5)
 	// _, err = s.Wait4response("AT+CNMI=3,2,0,0,0", CMD_OK, time.Second*5)
 	if err != nil {
@@ -421,6 +569,12 @@ func (s *SIM900) Init() error {
 		return err
 	}
 
+	// Enable unsolicited +CREG: URCs with LAC/CI, for WaitForRegistration
+	_, err = s.Wait4response("AT+CREG=2", CMD_OK, time.Second*5)
+	if err != nil {
+		return err
+	}
+
 	// get service number
 	csca, err := s.getCSCA()
 	if err != nil {
@@ -431,6 +585,49 @@ func (s *SIM900) Init() error {
 	return nil
 }
 
+// reassemble buffers fragments of a concatenated SMS (UDH IEI 0x00) keyed by (address, ref) until
+// all parts have arrived, then returns a single *sms.Message with the parts' text joined in order.
+// It returns nil while parts are still missing, and msg unchanged when msg isn't part of a concatenated SMS.
+func (s *SIM900) reassemble(msg *sms.Message) *sms.Message {
+	ref, total, seq, ok := parseConcatUDH(msg.UDH)
+	if !ok {
+		return msg
+	}
+
+	key := concatKey{address: string(msg.Address), ref: ref}
+
+	s.concatMu.Lock()
+	defer s.concatMu.Unlock()
+
+	parts, found := s.concatParts[key]
+	if !found {
+		parts = map[byte]*sms.Message{}
+		s.concatParts[key] = parts
+	}
+	parts[seq] = msg
+
+	if byte(len(parts)) < total {
+		return nil
+	}
+	delete(s.concatParts, key)
+
+	merged := *msg
+	merged.Text = ""
+	for i := byte(1); i <= total; i++ {
+		merged.Text += parts[i].Text
+	}
+	return &merged
+}
+
+// parseConcatUDH extracts the reference, total part count and sequence number from a
+// concatenated-SMS UDH (IEI 0x00, IEDL 0x03).
+func parseConcatUDH(udh []byte) (ref, total, seq byte, ok bool) {
+	if len(udh) < 5 || udh[0] != 0x00 || udh[1] != 0x03 {
+		return 0, 0, 0, false
+	}
+	return udh[2], udh[3], udh[4], true
+}
+
 func (s *SIM900) getCSCA() (string, error) {
 	response, err := s.Wait4response("AT+CSCA?", `(\+CSCA:\s*"(\+\d+)"($|\W))`, time.Second*3)
 	if err != nil {
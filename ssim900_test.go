@@ -1,20 +1,66 @@
-package sim900_test
-
-import (
-	"testing"
-
-	"github.com/vinhjaxt/sim900"
-)
-
-func TestSendSMS(t *testing.T) {
-	ss := sim900.New()
-	err := ss.Connect("COM23", 460800)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	_, err = ss.SendSMS("+84902107790", "Vá»‹nh")
-	if err != nil {
-		t.Fatal(err)
-	}
-}
+package sim900_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vinhjaxt/sim900"
+	"github.com/vinhjaxt/sim900/mock"
+	"github.com/xlab/at/sms"
+)
+
+func newTestModem(t *testing.T) (*sim900.SIM900, *mock.Transport) {
+	t.Helper()
+	tr := mock.New()
+	tr.ExpectOK("^AT$")
+	tr.ExpectOK("^ATE0$")
+	tr.ExpectOK(`^AT\+CMGF=0$`)
+	tr.ExpectOK(`^AT\+CNMI=`)
+	tr.ExpectOK(`^AT\+CPMS=`)
+	tr.ExpectOK(`^AT\+CLIP=1$`)
+	tr.ExpectOK(`^AT\+CREG=2$`)
+	tr.Expect(`^AT\+CSCA\?$`, []byte("\r\n+CSCA: \"+84999999999\"\r\nOK\r\n"))
+
+	ss := sim900.NewWithTransport(tr)
+	if err := ss.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return ss, tr
+}
+
+func TestSendSMS(t *testing.T) {
+	ss, tr := newTestModem(t)
+
+	tr.Expect(`AT\+CMGS=\d+`, []byte("\r\n> "))
+	tr.Expect("\x1A", []byte("\r\n+CMGS: 1\r\n"))
+
+	id, err := ss.SendSMS("+84902107790", "Vịnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "1" {
+		t.Fatalf("expected sms id 1, got %q", id)
+	}
+}
+
+func TestReceiveSMS(t *testing.T) {
+	ss, tr := newTestModem(t)
+
+	result := make(chan *sms.Message, 1)
+	ss.AddSMSListener(func(msg *sms.Message) {
+		result <- msg
+	})
+
+	if err := tr.InjectSMS("+84902107790", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-result:
+		if msg.Text != "hello" {
+			t.Fatalf("expected text %q, got %q", "hello", msg.Text)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for AddSMSListener to fire")
+	}
+}
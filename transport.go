@@ -0,0 +1,16 @@
+package sim900
+
+import "time"
+
+// Transport is the subset of *serial.SerialPort that SIM900 needs to talk to a modem.
+// It exists so tests (and anything else) can substitute an in-memory implementation
+// instead of real hardware; see the sim900/mock package for one.
+type Transport interface {
+	Print(str string) error
+	Println(str string) error
+	Printf(format string, args ...interface{}) error
+	WaitForRegexTimeout(cmd, exp string, timeout time.Duration, inits ...func() error) ([]string, error)
+	AddOutputListener(fn func([]byte)) uint32
+	DelOutputListener(id uint32)
+	Close() error
+}